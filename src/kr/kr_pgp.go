@@ -2,11 +2,15 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha1"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,12 +21,25 @@ import (
 )
 
 func globalGitUserIDOrFatal() string {
-	userID, err := GlobalGitUserId()
+	return gitUserIDOrFatal("")
+}
+
+// gitUserIDOrFatal resolves the git user identity configured for repo
+// (global config when repo is empty), fatally erroring with setup
+// instructions if name/email are not yet configured at that scope.
+func gitUserIDOrFatal(repo string) string {
+	userID, err := GitUserId(repo)
 	if err != nil {
+		nameHint := Cyan("git config --global user.name \"FirstName LastName\"")
+		emailHint := Cyan("git config --global user.email Email")
+		if repo != "" {
+			nameHint = Cyan("git config user.name \"FirstName LastName\"")
+			emailHint = Cyan("git config user.email Email")
+		}
 		PrintFatal(os.Stderr, Red("Your git name and email are not yet configured. Please run "+
-			Cyan("git config --global user.name \"FirstName LastName\"")+
+			nameHint+
 			" and "+
-			Cyan("git config --global user.email Email")+
+			emailHint+
 			" before running "+
 			Cyan("kr codesign")))
 	}
@@ -32,8 +49,28 @@ func globalGitUserIDOrFatal() string {
 	return userID
 }
 
+// repoFromContext returns the repository path a --repo or --local
+// codesign invocation should operate on, or "" for the global scope.
+func repoFromContext(c *cli.Context) string {
+	if repo := c.String("repo"); repo != "" {
+		return repo
+	}
+	if c.Bool("local") {
+		wd, err := os.Getwd()
+		if err == nil {
+			return wd
+		}
+	}
+	return ""
+}
+
 func codesignCommand(c *cli.Context) (err error) {
 	stderr := os.Stderr
+
+	if c.Bool("print-shell-snippet") {
+		return codesignPrintShellSnippetCommand(c)
+	}
+
 	latestKrdRunning, err := IsLatestKrdRunning()
 	if err != nil || !latestKrdRunning {
 		PrintFatal(stderr, ErrOldKrdRunning.Error())
@@ -46,14 +83,23 @@ func codesignCommand(c *cli.Context) (err error) {
 		Analytics{}.PostEventUsingPersistedTrackingID("kr", "codesign", nil, nil)
 	}()
 
-	userID := globalGitUserIDOrFatal()
+	repo := repoFromContext(c)
+	userID := gitUserIDOrFatal(repo)
 
-	//	explicitly ask phone, disregarding cached ME in case the phone did not support PGP when first paired
+	// explicitly ask phone, disregarding cached ME in case the phone did not support PGP when first paired
 	me, err := RequestMeForceRefresh(&userID)
 	if err != nil {
 		PrintFatal(stderr, err.Error())
 	}
 
+	if c.Bool("ssh") {
+		return codesignSSHCommand(c, userID, me)
+	}
+
+	if repo != "" {
+		return codesignLocalCommand(c, repo, userID, me)
+	}
+
 	pk, err := me.AsciiArmorPGPPublicKey()
 	if err != nil {
 		PrintFatal(stderr, "You do not yet have a PGP public key. Make sure you have the latest version of the Krypton app and try again.")
@@ -65,7 +111,7 @@ func codesignCommand(c *cli.Context) (err error) {
 	}
 	krGPGPath := strings.TrimSpace(string(whichKrGPG))
 
-	err = exec.Command("git", "config", "--global", "gpg.program", krGPGPath).Run()
+	err = exec.Command("git", "config", "--global", "gpg.program", krGPGWrapperPath(krGPGPath)).Run()
 	if err != nil {
 		PrintFatal(os.Stderr, err.Error())
 	}
@@ -85,6 +131,190 @@ func codesignCommand(c *cli.Context) (err error) {
 	return
 }
 
+// codesignSSHCommand sets up commit signing using git's "ssh" gpg.format
+// (git >= 2.34) instead of PGP, routing the actual signature through krd's
+// existing SSH signing flow rather than ssh-agent. Git only consults
+// gpg.program for gpg.format=openpgp, so this also points gpg.ssh.program
+// (which git actually invokes to sign/verify under gpg.format=ssh, in
+// place of its default ssh-keygen) at the same krgpg wrapper.
+func codesignSSHCommand(c *cli.Context, userID string, me Profile) (err error) {
+	stderr := os.Stderr
+
+	sshPk, err := me.AuthorizedKeyString()
+	if err != nil {
+		PrintFatal(stderr, "You do not yet have an SSH public key. Make sure you have the latest version of the Krypton app and try again.")
+	}
+
+	whichKrGPG, err := exec.Command("which", "krgpg").Output()
+	if err != nil {
+		PrintFatal(stderr, "Could not find krgpg: "+err.Error())
+	}
+	krGPGPath := krGPGWrapperPath(strings.TrimSpace(string(whichKrGPG)))
+
+	email := emailFromUserID(userID)
+	if email == "" {
+		PrintFatal(stderr, "Could not determine your email from git identity \""+userID+"\"")
+	}
+	if err = writeAllowedSigner(email, sshPk); err != nil {
+		PrintFatal(stderr, "Failed to write ~/.ssh/allowed_signers: "+err.Error())
+	}
+
+	for _, setting := range [][]string{
+		{"gpg.program", krGPGPath},
+		{"gpg.format", "ssh"},
+		{"gpg.ssh.program", krGPGPath},
+		{"gpg.ssh.allowedSignersFile", allowedSignersPath()},
+		{"user.signingkey", sshPk},
+	} {
+		if err = exec.Command("git", append([]string{"config", "--global", setting[0]}, setting[1])...).Run(); err != nil {
+			PrintFatal(stderr, err.Error())
+		}
+	}
+
+	os.Stderr.WriteString("Code signing configured using your " + Cyan("SSH public key") + " via " + Cyan("gpg.format=ssh") + "\r\n")
+
+	interactive := c.Bool("interactive")
+	onboardAutoCommitSign(interactive)
+	onboardGPG_TTY(interactive)
+
+	return
+}
+
+// codesignLocalCommand configures commit.gpgSign, tag.forceSignAnnotated,
+// gpg.program, and a chosen signing identity in repo's local git config
+// only, leaving the user's global config (and any other identity they use
+// elsewhere) untouched.
+func codesignLocalCommand(c *cli.Context, repo string, userID string, me Profile) (err error) {
+	stderr := os.Stderr
+
+	identity, err := chooseSigningIdentity(c, userID, me)
+	if err != nil {
+		PrintFatal(stderr, err.Error())
+	}
+
+	whichKrGPG, err := exec.Command("which", "krgpg").Output()
+	if err != nil {
+		PrintFatal(stderr, "Could not find krgpg: "+err.Error())
+	}
+	krGPGPath := krGPGWrapperPath(strings.TrimSpace(string(whichKrGPG)))
+
+	for _, setting := range [][]string{
+		{"gpg.program", krGPGPath},
+		{"commit.gpgSign", "true"},
+		{"tag.forceSignAnnotated", "true"},
+		{"user.signingkey", identity},
+	} {
+		if err = exec.Command("git", "-C", repo, "config", setting[0], setting[1]).Run(); err != nil {
+			PrintFatal(stderr, err.Error())
+		}
+	}
+
+	os.Stderr.WriteString(Green("Codesigning configured for this repository only ✔")+" ("+Cyan(identity)+" in "+repo+")\r\n")
+	return
+}
+
+// signingIdentities returns the git user identities (name + email) the
+// paired phone is able to sign commits for.
+func signingIdentities(me Profile) ([]string, error) {
+	return me.PGPUserIDs()
+}
+
+// codesignIdentitiesCommand lists the identities the paired phone can
+// sign for, so the user knows what to pass to `kr codesign --local
+// --identity`.
+func codesignIdentitiesCommand(c *cli.Context) (err error) {
+	stderr := os.Stderr
+	userID := globalGitUserIDOrFatal()
+
+	me, err := RequestMeForceRefresh(&userID)
+	if err != nil {
+		PrintFatal(stderr, err.Error())
+	}
+
+	identities, err := signingIdentities(me)
+	if err != nil {
+		PrintFatal(stderr, err.Error())
+	}
+	if len(identities) == 0 {
+		os.Stderr.WriteString("No signing identities are available on your paired phone.\r\n")
+		return
+	}
+
+	os.Stderr.WriteString("Identities available for signing:\r\n")
+	for i, identity := range identities {
+		fmt.Fprintf(stderr, "  %d) %s\r\n", i+1, identity)
+	}
+	os.Stderr.WriteString("\r\nUse one for this repository by running " + Cyan("kr codesign --local --identity <email>") + "\r\n")
+	return
+}
+
+// chooseSigningIdentity picks which of the phone's available identities to
+// sign with in a --local repo: an explicit --identity flag wins, otherwise
+// a single available identity is used automatically, otherwise the user is
+// prompted interactively, falling back to the git-configured userID.
+func chooseSigningIdentity(c *cli.Context, userID string, me Profile) (identity string, err error) {
+	if requested := c.String("identity"); requested != "" {
+		return requested, nil
+	}
+
+	identities, err := signingIdentities(me)
+	if err != nil || len(identities) == 0 {
+		return userID, nil
+	}
+	if len(identities) == 1 {
+		return identities[0], nil
+	}
+	if !c.Bool("interactive") {
+		return userID, nil
+	}
+
+	os.Stderr.WriteString("Choose a signing identity for this repository:\r\n")
+	for i, candidate := range identities {
+		fmt.Fprintf(os.Stderr, "  %d) %s\r\n", i+1, candidate)
+	}
+	os.Stderr.WriteString("> ")
+	var choice int
+	if _, scanErr := fmt.Scanln(&choice); scanErr == nil && choice >= 1 && choice <= len(identities) {
+		return identities[choice-1], nil
+	}
+	return userID, nil
+}
+
+// allowedSignersPath returns the path git's gpg.ssh.allowedSignersFile is
+// configured to. It does not create the file or its ~/.ssh directory;
+// writeAllowedSigner does that.
+func allowedSignersPath() string {
+	home := os.Getenv("HOME")
+	return filepath.Join(home, ".ssh", "allowed_signers")
+}
+
+// writeAllowedSigner appends the "principal email key" line for email to
+// ~/.ssh/allowed_signers, creating the file and its directory if needed, and
+// leaves the file unchanged if sshPk already appears anywhere in it (an
+// existing line for that key is not updated), per git's documented format for
+// gpg.ssh.allowedSignersFile. The principal must be a single token, so
+// callers pass a bare email rather than the full "Name <email>" git identity.
+func writeAllowedSigner(email string, sshPk string) (err error) {
+	path := allowedSignersPath()
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	line := email + " " + sshPk + "\n"
+
+	existing, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(existing), sshPk) {
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, err = f.WriteString(line)
+	return
+}
+
 func runCommandWithOutputOrFatal(cmd *exec.Cmd) {
 	out, err := cmd.CombinedOutput()
 	if err != nil {
@@ -102,6 +332,12 @@ func codesignOnCommand(c *cli.Context) (err error) {
 
 func codesignOffCommand(c *cli.Context) (err error) {
 	exec.Command("git", "config", "--global", "--unset", "commit.gpgSign").Run()
+	if c.Bool("ssh") {
+		exec.Command("git", "config", "--global", "--unset", "gpg.format").Run()
+		exec.Command("git", "config", "--global", "--unset", "gpg.ssh.program").Run()
+		exec.Command("git", "config", "--global", "--unset", "gpg.ssh.allowedSignersFile").Run()
+		exec.Command("git", "config", "--global", "--unset", "user.signingkey").Run()
+	}
 	PrintErr(os.Stderr, "Automatic commit signing disabled. Sign a new commit by running "+Cyan("git commit -S")+" or sign your last commit by running "+Cyan("git commit --amend -S")+"\r\nRe-enable automatic commit signing by running "+Cyan("kr codesign on"))
 	return
 }
@@ -121,8 +357,248 @@ func codesignTestCommand(c *cli.Context) (err error) {
 	return
 }
 
+const defaultKeyserver = "hkps://keys.openpgp.org"
+
+// keyserverURL returns the keyserver `kr codesign verify` falls back to
+// when a signing key is missing locally. Overridable by `kr set keyserver
+// <url>`, which persists to git's kr.keyserver config key.
+func keyserverURL() string {
+	out, err := exec.Command("git", "config", "--global", "kr.keyserver").Output()
+	if err != nil {
+		return defaultKeyserver
+	}
+	url := strings.TrimSpace(string(out))
+	if url == "" {
+		return defaultKeyserver
+	}
+	return url
+}
+
+// signatureStatus is the machine-readable summary `kr codesign verify`
+// emits per ref, so CI can gate on it without parsing gpg's own output.
+type signatureStatus struct {
+	Ref      string `json:"ref"`
+	Verified bool   `json:"verified"`
+	Status   string `json:"status"` // "good", "bad", or "unknown"
+	Signer   string `json:"signer,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// codesignVerifyCommand runs `git verify-commit`/`git verify-tag` over a
+// ref or range, transparently fetching missing signer keys via WKD or the
+// configured keyserver and re-verifying, then prints a JSON summary.
+func codesignVerifyCommand(c *cli.Context) (err error) {
+	stderr := os.Stderr
+	refs, err := refsToVerify(c)
+	if err != nil {
+		PrintFatal(stderr, err.Error())
+	}
+
+	statuses := make([]signatureStatus, 0, len(refs))
+	allGood := true
+	for _, ref := range refs {
+		status := verifyRef(ref)
+		statuses = append(statuses, status)
+		if status.Status != "good" {
+			allGood = false
+		}
+	}
+
+	out, jsonErr := json.MarshalIndent(statuses, "", "  ")
+	if jsonErr != nil {
+		PrintFatal(stderr, jsonErr.Error())
+	}
+	fmt.Println(string(out))
+
+	if !allGood {
+		err = fmt.Errorf("one or more refs failed signature verification")
+	}
+	return
+}
+
+// refsToVerify resolves the `kr codesign verify` argument (a single ref,
+// defaulting to HEAD, or a "a..b" range) into the commits/tags to check.
+func refsToVerify(c *cli.Context) ([]string, error) {
+	arg := c.Args().First()
+	if arg == "" {
+		arg = "HEAD"
+	}
+	if strings.Contains(arg, "..") {
+		out, err := exec.Command("git", "rev-list", arg).Output()
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve range %s: %s", arg, err.Error())
+		}
+		return strings.Fields(string(out)), nil
+	}
+	return []string{arg}, nil
+}
+
+// verifyRef runs git verify-commit/verify-tag on ref, fetching the
+// signer's key via WKD or the configured keyserver and retrying once if
+// gpg reports the key is missing.
+func verifyRef(ref string) signatureStatus {
+	status := signatureStatus{Ref: ref}
+
+	out, err := gitVerify(ref)
+	if err == nil {
+		status.Verified = true
+		status.Status = "good"
+		status.Signer = signerFromVerifyOutput(out)
+		return status
+	}
+
+	keyID := missingKeySignerKeyID(out)
+	if keyID == "" {
+		if badSignatureInVerifyOutput(out) {
+			status.Status = "bad"
+		} else {
+			status.Status = "unknown"
+		}
+		status.Error = strings.TrimSpace(out)
+		return status
+	}
+
+	if fetchErr := fetchMissingKey(refSignerEmailHint(ref), keyID); fetchErr != nil {
+		status.Status = "unknown"
+		status.Error = fetchErr.Error()
+		return status
+	}
+
+	out, err = gitVerify(ref)
+	if err != nil {
+		status.Status = "bad"
+		status.Error = strings.TrimSpace(out)
+		return status
+	}
+	status.Verified = true
+	status.Status = "good"
+	status.Signer = signerFromVerifyOutput(out)
+	return status
+}
+
+// gitVerify runs `git verify-commit` on ref, falling back to `git
+// verify-tag`, returning gpg's combined --raw status output.
+func gitVerify(ref string) (output string, err error) {
+	out, err := exec.Command("git", "verify-commit", "--raw", ref).CombinedOutput()
+	if err == nil {
+		return string(out), nil
+	}
+	tagOut, tagErr := exec.Command("git", "verify-tag", "--raw", ref).CombinedOutput()
+	if tagErr == nil {
+		return string(tagOut), nil
+	}
+	return string(out), err
+}
+
+func signerFromVerifyOutput(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "GOODSIG") {
+			fields := strings.Fields(line)
+			if len(fields) > 3 {
+				return strings.Join(fields[3:], " ")
+			}
+		}
+	}
+	return ""
+}
+
+// badSignatureInVerifyOutput reports whether gpg's raw status output
+// reports an actual signature failure (wrong key, tampered content,
+// expired/revoked signing key) as opposed to a key we simply don't have --
+// the distinction the "bad" vs "unknown" status is for.
+func badSignatureInVerifyOutput(out string) bool {
+	for _, marker := range []string{"BADSIG", "EXPSIG", "EXPKEYSIG", "REVKEYSIG", "ERRSIG"} {
+		if strings.Contains(out, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingKeySignerKeyID extracts the signer's long key ID from gpg's raw
+// status output when it reports the public key as not found locally. This
+// is a key ID, not an email -- too little on its own for a WKD lookup, see
+// refSignerEmailHint.
+func missingKeySignerKeyID(out string) string {
+	if !strings.Contains(out, "NO_PUBKEY") {
+		return ""
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "NO_PUBKEY") {
+			fields := strings.Fields(line)
+			if len(fields) > 1 {
+				return fields[len(fields)-1]
+			}
+		}
+	}
+	return ""
+}
+
+// refSignerEmailHint returns the email most likely to belong to ref's
+// signer -- the tagger for an annotated tag, otherwise the commit
+// author -- so a missing key can be located via WKD, which needs an
+// "@domain" to resolve and can't work from a key ID alone.
+func refSignerEmailHint(ref string) string {
+	if tagOut, err := exec.Command("git", "cat-file", "tag", ref).Output(); err == nil {
+		for _, line := range strings.Split(string(tagOut), "\n") {
+			if strings.HasPrefix(line, "tagger ") {
+				return emailFromUserID(strings.TrimPrefix(line, "tagger "))
+			}
+		}
+	}
+	authorOut, err := exec.Command("git", "log", "-1", "--format=%ae", ref).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(authorOut))
+}
+
+// fetchMissingKey imports keyID's PGP key via WKD (when email is known --
+// WKD can only resolve a mailbox, not a bare key ID), falling back to the
+// configured keyserver by keyID, then marks it with a low ownertrust level
+// so verification succeeds without fully vetting the key.
+func fetchMissingKey(email, keyID string) error {
+	if email != "" {
+		if err := exec.Command("gpg", "--auto-key-locate", "wkd,keyserver", "--keyserver", keyserverURL(), "--locate-keys", email).Run(); err == nil {
+			return setLowOwnertrust(keyID)
+		}
+	}
+	out, err := exec.Command("gpg", "--keyserver", keyserverURL(), "--recv-keys", keyID).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not fetch key %s via WKD or %s: %s", keyID, keyserverURL(), strings.TrimSpace(string(out)))
+	}
+	return setLowOwnertrust(keyID)
+}
+
+// setLowOwnertrust marks keyID as marginally trusted, following the same
+// `gpg --import-ownertrust` pattern onboardLocalGPG uses for the user's
+// own key, but at a low trust level since we did not verify the key
+// ourselves.
+func setLowOwnertrust(keyID string) error {
+	out, err := exec.Command("gpg", "--with-colons", "--list-keys", keyID).Output()
+	if err != nil {
+		return err
+	}
+	fpr := ""
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "fpr:") {
+			fields := strings.Split(line, ":")
+			if len(fields) > 9 {
+				fpr = fields[9]
+				break
+			}
+		}
+	}
+	if fpr == "" {
+		return nil
+	}
+	cmdTrust := exec.Command("gpg", "--import-ownertrust")
+	cmdTrust.Stdin = bytes.NewReader([]byte(fpr + ":4:\r\n"))
+	return cmdTrust.Run()
+}
+
 func codesignUninstallCommand(c *cli.Context) (err error) {
-	uninstallCodesigning()
+	uninstallCodesigningForScope(repoFromContext(c))
 	os.Stderr.WriteString("Krypton codesigning uninstalled... run " + Cyan("kr codesign") + " to reinstall.\r\n")
 	return
 }
@@ -161,12 +637,64 @@ func onboardAutoCommitSign(interactive bool) {
 	<-time.After(500 * time.Millisecond)
 }
 
-func shellRCFileAndGPG_TTYExport() (file string, export string) {
+// shellFromEnv returns $SHELL, falling back to the shell recorded for the
+// current user in /etc/passwd when $SHELL is unset -- e.g. some non-login
+// invocations, display managers, and containers don't set it.
+func shellFromEnv() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return shellFromPasswd(os.Getenv("USER"))
+}
+
+func shellFromPasswd(user string) string {
+	if user == "" {
+		return ""
+	}
+	passwd, err := ioutil.ReadFile("/etc/passwd")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(passwd), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) == 7 && fields[0] == user {
+			return fields[6]
+		}
+	}
+	return ""
+}
+
+// ensureBashProfileSourcesBashRC makes .bash_profile source .bashrc
+// (idempotently), matching how most Linux distributions already wire
+// login/non-login bash together. Without this, an export written only to
+// .bashrc is invisible to login shells (e.g. macOS Terminal.app) that read
+// .bash_profile instead, and vice versa for some Linux login managers.
+func ensureBashProfileSourcesBashRC(bashProfile, bashRc string) {
+	guard := "[ -f " + bashRc + " ] && source " + bashRc
+	contents, err := ioutil.ReadFile(bashProfile)
+	if err == nil && strings.Contains(string(contents), bashRc) {
+		return
+	}
+	f, err := os.OpenFile(bashProfile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0755)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString("\n# Added by Krypton\n" + guard + "\n")
+}
+
+// shellRCFilesAndGPG_TTYExport returns the rc file(s) to add a GPG_TTY
+// export to for the user's shell, and the shell-appropriate export syntax.
+// Bash returns both .bash_profile and .bashrc since which one a given
+// terminal/login mode sources is inconsistent across platforms, except when
+// .bash_login exists without a .bash_profile, where only .bash_login is
+// returned.
+func shellRCFilesAndGPG_TTYExport() (files []string, export string) {
 	exists := func(file string) bool {
 		_, err := os.Stat(file)
 		return err == nil
 	}
-	shell := os.Getenv("SHELL")
+	shell := shellFromEnv()
 	home := os.Getenv("HOME")
 
 	zshrc := filepath.Join(home, ".zshrc")
@@ -177,45 +705,115 @@ func shellRCFileAndGPG_TTYExport() (file string, export string) {
 
 	kshRc := filepath.Join(home, ".kshrc")
 	cshRc := filepath.Join(home, ".cshrc")
+	tcshRc := filepath.Join(home, ".tcshrc")
 	fishConfig := filepath.Join(home, ".config", "fish", "config.fish")
-	if strings.Contains(shell, "zsh") {
-		return zshrc, "export GPG_TTY=$(tty)"
-	} else if strings.Contains(shell, "bash") && exists(bashProfile) {
-		return bashProfile, "export GPG_TTY=$(tty)"
-	} else if strings.Contains(shell, "bash") && exists(bashLogin) {
-		return bashLogin, "export GPG_TTY=$(tty)"
-	} else if strings.Contains(shell, "bash") && exists(bashRc) {
-		return bashRc, "export GPG_TTY=$(tty)"
-	} else if strings.Contains(shell, "ksh") {
-		return kshRc, "export GPG_TTY=$(tty)"
-	} else if strings.Contains(shell, "csh") {
-		return cshRc, "setenv GPG_TTY `tty`"
-	} else if strings.Contains(shell, "fish") {
-		return fishConfig, "set -x GPG_TTY (tty)"
-	} else {
-		return profile, "export GPG_TTY=$(tty)"
+	nuConfig := filepath.Join(home, ".config", "nushell", "env.nu")
+	elvishRc := filepath.Join(home, ".config", "elvish", "rc.elv")
+	xonshRc := filepath.Join(home, ".xonshrc")
+
+	switch {
+	case strings.Contains(shell, "zsh"):
+		return []string{zshrc}, "export GPG_TTY=$(tty)"
+	case strings.Contains(shell, "bash") && exists(bashLogin) && !exists(bashProfile):
+		return []string{bashLogin}, "export GPG_TTY=$(tty)"
+	case strings.Contains(shell, "bash"):
+		ensureBashProfileSourcesBashRC(bashProfile, bashRc)
+		return []string{bashProfile, bashRc}, "export GPG_TTY=$(tty)"
+	case strings.Contains(shell, "ksh"):
+		return []string{kshRc}, "export GPG_TTY=$(tty)"
+	case strings.Contains(shell, "tcsh"):
+		return []string{tcshRc}, "setenv GPG_TTY `tty`"
+	case strings.Contains(shell, "csh"):
+		return []string{cshRc}, "setenv GPG_TTY `tty`"
+	case strings.Contains(shell, "fish"):
+		return []string{fishConfig}, "set -x GPG_TTY (tty)"
+	case strings.Contains(shell, "nu"):
+		return []string{nuConfig}, "$env.GPG_TTY = (tty)"
+	case strings.Contains(shell, "elvish"):
+		return []string{elvishRc}, "set-env GPG_TTY (tty)"
+	case strings.Contains(shell, "xonsh"):
+		return []string{xonshRc}, "$GPG_TTY = $(tty).strip()"
+	default:
+		return []string{profile}, "export GPG_TTY=$(tty)"
 	}
 }
 
-func addGPG_TTYExportToCurrentShellIfNotPresent() (path, cmd string) {
-	path, cmd = shellRCFileAndGPG_TTYExport()
-	rcContents, err := ioutil.ReadFile(path)
-	if err == nil {
-		if strings.Contains(string(rcContents), cmd) {
-			return
-		}
+// gpgTTYAlreadyExported reports whether rcContents already exports
+// GPG_TTY in some form -- ours, or one written by another tool (gpg-agent
+// setup guides, dotfiles frameworks, propellor, etc.) -- so we don't add a
+// second, possibly conflicting, export.
+func gpgTTYAlreadyExported(rcContents string) bool {
+	return strings.Contains(rcContents, "GPG_TTY")
+}
+
+func addGPG_TTYExportToCurrentShellIfNotPresent() (paths []string, cmd string) {
+	var files []string
+	files, cmd = shellRCFilesAndGPG_TTYExport()
+	// only the bash case returns two files: .bash_profile, then .bashrc
+	if len(files) == 2 {
+		ensureBashProfileSourcesBashRC(files[0], files[1])
 	}
-	rcFile, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0755)
-	if err != nil {
-		return
+	for _, path := range files {
+		rcContents, err := ioutil.ReadFile(path)
+		if err == nil && gpgTTYAlreadyExported(string(rcContents)) {
+			continue
+		}
+		rcFile, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0755)
+		if err != nil {
+			continue
+		}
+		rcFile.WriteString("\n# Added by Krypton\n" + cmd + "\n")
+		rcFile.Close()
+		paths = append(paths, path)
 	}
-	//	seek to end
-	rcFile.Seek(0, 2)
-	rcFile.WriteString("\n# Added by Krypton\n" + cmd + "\n")
-	rcFile.Close()
 	return
 }
 
+// codesignPrintShellSnippetCommand implements `kr codesign
+// --print-shell-snippet`: prints the GPG_TTY export line for the current
+// shell without touching any files, so package managers and dotfiles
+// frameworks can splice it into their own managed rc files themselves.
+func codesignPrintShellSnippetCommand(c *cli.Context) (err error) {
+	_, export := shellRCFilesAndGPG_TTYExport()
+	fmt.Println(export)
+	return
+}
+
+// shellSingleQuote single-quotes s for safe embedding in a generated
+// POSIX sh script, escaping any single quotes s itself contains.
+func shellSingleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// krGPGWrapperPath returns the path to a small scratch wrapper script that
+// execs krGPGPath with GPG_TTY populated from `tty` whenever it's unset,
+// so a git signature still has a terminal to prompt on in the current
+// shell session even before the user restarts it to pick up the GPG_TTY
+// export onboardGPG_TTY below adds to their rc file. Falls back to
+// krGPGPath itself if the wrapper can't be written.
+func krGPGWrapperPath(krGPGPath string) string {
+	dir := filepath.Join(os.Getenv("HOME"), ".kr")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return krGPGPath
+	}
+	path := filepath.Join(dir, "krgpg-wrapper.sh")
+	script := "#!/bin/sh\n" +
+		"if [ -z \"$GPG_TTY\" ]; then\n" +
+		"\tGPG_TTY=$(tty 2>/dev/null)\n" +
+		"\texport GPG_TTY\n" +
+		"fi\n" +
+		"exec " + shellSingleQuote(krGPGPath) + " \"$@\"\n"
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		return krGPGPath
+	}
+	return path
+}
+
+// onboardGPG_TTY makes sure GPG_TTY is available so gpg/krgpg can prompt
+// on the right terminal. Shell startup is the durable fix (below); the
+// krgpg-wrapper.sh script gpg.program/gpg.ssh.program actually point at
+// (see krGPGWrapperPath) falls back to `tty` at runtime when GPG_TTY is
+// unset, so signing still works before the user restarts their shell.
 func onboardGPG_TTY(interactive bool) {
 	cmd := "export GPG_TTY=$(tty); "
 	if os.Getenv("GPG_TTY") == "" {
@@ -239,19 +837,188 @@ func onboardGPG_TTY(interactive bool) {
 func onboardKeyServerUpload(interactive bool, pk string) {
 	var uploadKey bool
 	if interactive {
-		if confirm(os.Stderr, "In order for other people to verify your commits, they need to be able to download your public key. Would you like to "+Cyan("upload your public key to the MIT keyserver")+"?") {
+		if confirm(os.Stderr, "In order for other people to verify your commits, they need to be able to download your public key. Would you like to "+Cyan("upload your public key to keys.openpgp.org")+"?") {
 			uploadKey = true
 		}
 	}
 	if uploadKey || !interactive {
-		cmd := exec.Command("curl", "https://pgp.mit.edu/pks/add", "-f", "--data-urlencode", "keytext="+pk)
-		output, err := cmd.CombinedOutput()
-		if err == nil {
-			os.Stderr.WriteString(Green("Key uploaded ✔\r\n"))
-		} else {
-			os.Stderr.WriteString(Red("Failed to upload key, curl output:\r\n" + string(output) + "\r\n"))
+		uploadPublicKeyToKeysOpenPGPOrg(pk)
+	}
+}
+
+const (
+	keysOpenPGPOrgUploadURL = "https://keys.openpgp.org/vks/v1/upload"
+	keysOpenPGPOrgVerifyURL = "https://keys.openpgp.org/vks/v1/request-verify"
+)
+
+// httpClient bounds requests to keys.openpgp.org and user-configured proof
+// upload endpoints so a slow or unreachable server can't hang kr forever.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// keysOpenPGPOrgUploadResponse is the subset of the Verifying Keyserver
+// upload response (https://keys.openpgp.org/about/api) kr needs: the
+// per-address publication status, and the token used to request
+// verification of any address that isn't published yet.
+type keysOpenPGPOrgUploadResponse struct {
+	KeyFpr string            `json:"key_fpr"`
+	Status map[string]string `json:"status"`
+	Token  string            `json:"token"`
+}
+
+// uploadPublicKeyToKeysOpenPGPOrg submits pk to keys.openpgp.org, which
+// unlike the old MIT keyserver requires confirming ownership of any email
+// addresses on the key before they become searchable by email.
+func uploadPublicKeyToKeysOpenPGPOrg(pk string) {
+	uploadBody, err := json.Marshal(map[string]string{"keytext": pk})
+	if err != nil {
+		os.Stderr.WriteString(Red("Failed to upload key: " + err.Error() + "\r\n"))
+		return
+	}
+	uploadResp, err := httpClient.Post(keysOpenPGPOrgUploadURL, "application/json", bytes.NewReader(uploadBody))
+	if err != nil {
+		os.Stderr.WriteString(Red("Failed to upload key: " + err.Error() + "\r\n"))
+		return
+	}
+	defer uploadResp.Body.Close()
+	uploadRespBody, err := ioutil.ReadAll(uploadResp.Body)
+	if err != nil || uploadResp.StatusCode != 200 {
+		os.Stderr.WriteString(Red("Failed to upload key, keys.openpgp.org response:\r\n" + string(uploadRespBody) + "\r\n"))
+		return
+	}
+
+	var upload keysOpenPGPOrgUploadResponse
+	if err = json.Unmarshal(uploadRespBody, &upload); err != nil {
+		os.Stderr.WriteString(Red("Failed to parse keys.openpgp.org response: " + err.Error() + "\r\n"))
+		return
+	}
+	os.Stderr.WriteString(Green("Key uploaded to keys.openpgp.org ✔\r\n"))
+
+	unverified := map[string]bool{}
+	for email, status := range upload.Status {
+		if status != "published" {
+			unverified[email] = true
+		}
+	}
+	if upload.Token == "" || len(unverified) == 0 {
+		return
+	}
+
+	verifyBody, err := json.Marshal(map[string]interface{}{
+		"token":     upload.Token,
+		"addresses": unverified,
+	})
+	if err != nil {
+		os.Stderr.WriteString(Red("Failed to request verification: " + err.Error() + "\r\n"))
+		return
+	}
+	verifyResp, err := httpClient.Post(keysOpenPGPOrgVerifyURL, "application/json", bytes.NewReader(verifyBody))
+	if err != nil {
+		os.Stderr.WriteString(Red("Failed to request verification: " + err.Error() + "\r\n"))
+		return
+	}
+	defer verifyResp.Body.Close()
+	if verifyResp.StatusCode != 200 {
+		verifyRespBody, _ := ioutil.ReadAll(verifyResp.Body)
+		os.Stderr.WriteString(Red("Failed to request verification, keys.openpgp.org response:\r\n" + string(verifyRespBody) + "\r\n"))
+		return
+	}
+
+	os.Stderr.WriteString("Check your email and click the confirmation link(s) to make your key searchable on " + Cyan("keys.openpgp.org") + "\r\n")
+}
+
+const wkdZBase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+
+// wkdAdvancedMethodLocalPart implements WKD's "advanced method" mapping
+// from a mailbox local part to its published filename: lowercase it,
+// SHA-1 hash it, and z-base-32 encode the digest.
+func wkdAdvancedMethodLocalPart(localPart string) string {
+	sum := sha1.Sum([]byte(strings.ToLower(localPart)))
+	var out bytes.Buffer
+	var buffer uint32
+	var bitsInBuffer uint
+	for _, b := range sum {
+		buffer = (buffer << 8) | uint32(b)
+		bitsInBuffer += 8
+		for bitsInBuffer >= 5 {
+			bitsInBuffer -= 5
+			out.WriteByte(wkdZBase32Alphabet[(buffer>>bitsInBuffer)&0x1f])
 		}
 	}
+	if bitsInBuffer > 0 {
+		out.WriteByte(wkdZBase32Alphabet[(buffer<<(5-bitsInBuffer))&0x1f])
+	}
+	return out.String()
+}
+
+// writeWKDPublishHint writes a ready-to-publish WKD "advanced method" file
+// (openpgpkey/hu/<zbase32-localpart>) for email under a scratch temp
+// directory, plus printed guidance for the _openpgpkey.<domain> DNS setup,
+// for users who own their domain and would rather serve their key via WKD
+// than rely on a keyserver. Writing under a temp directory (rather than the
+// current directory, which is normally inside a git repo when this runs)
+// avoids leaving an openpgpkey/ directory in the user's working tree.
+func writeWKDPublishHint(email string, pk string) error {
+	parts := strings.SplitN(email, "@", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid email: %s", email)
+	}
+	localPart, domain := parts[0], parts[1]
+
+	scratchDir, err := ioutil.TempDir("", "kr-wkd-publish")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(scratchDir, "openpgpkey", "hu")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	filename := wkdAdvancedMethodLocalPart(localPart)
+	path := filepath.Join(dir, filename)
+	if err := ioutil.WriteFile(path, []byte(pk), 0644); err != nil {
+		return err
+	}
+
+	os.Stderr.WriteString("\r\nWrote " + Cyan(path) + ". If you own " + Cyan(domain) + ", you can serve your key via WKD instead of (or in addition to) a keyserver:\r\n" +
+		"  1) Add a CNAME from " + Cyan("openpgpkey."+domain) + " to your webserver, and a DNS hint record at " + Cyan("_openpgpkey."+domain) + " pointing clients at it\r\n" +
+		"  2) Copy " + Cyan(path) + " to " + Cyan("https://openpgpkey."+domain+"/.well-known/openpgpkey/hu/"+filename) + " on your webserver\r\n")
+	return nil
+}
+
+// emailFromUserID extracts the email from a "Name <email>" git user
+// identity string, as returned by GlobalGitUserId/GitUserId.
+func emailFromUserID(userID string) string {
+	start := strings.Index(userID, "<")
+	end := strings.Index(userID, ">")
+	if start == -1 || end == -1 || end < start {
+		return ""
+	}
+	return userID[start+1 : end]
+}
+
+// codesignPublishCommand re-runs just the key-distribution step of
+// onboarding: uploading to keys.openpgp.org and writing a WKD publish
+// hint, without touching any other git config.
+func codesignPublishCommand(c *cli.Context) (err error) {
+	stderr := os.Stderr
+	userID := globalGitUserIDOrFatal()
+
+	me, err := RequestMeForceRefresh(&userID)
+	if err != nil {
+		PrintFatal(stderr, err.Error())
+	}
+	pk, err := me.AsciiArmorPGPPublicKey()
+	if err != nil {
+		PrintFatal(stderr, "You do not yet have a PGP public key. Make sure you have the latest version of the Krypton app and try again.")
+	}
+
+	uploadPublicKeyToKeysOpenPGPOrg(pk)
+
+	if email := emailFromUserID(userID); email != "" {
+		if hintErr := writeWKDPublishHint(email, pk); hintErr != nil {
+			os.Stderr.WriteString(Red("Failed to write WKD publish file: " + hintErr.Error() + "\r\n"))
+		}
+	}
+	return
 }
 
 func onboardLocalGPG(interactive bool, me Profile) {
@@ -318,14 +1085,219 @@ func checkGitLocation() {
 }
 
 func uninstallCodesigning() {
-	currentGPGProgram, err := exec.Command("git", "config", "--global", "gpg.program").Output()
+	uninstallCodesigningForScope("")
+}
+
+// uninstallCodesigningForScope undoes whatever `kr codesign` (global),
+// `kr codesign --local`/`--repo`, or `kr codesign --ssh` configured, at the
+// given repo scope (global config when repo is empty).
+func uninstallCodesigningForScope(repo string) {
+	gitConfigArgs := func(args ...string) []string {
+		if repo == "" {
+			return append([]string{"config", "--global"}, args...)
+		}
+		return append([]string{"-C", repo, "config"}, args...)
+	}
+
+	currentGPGProgram, err := exec.Command("git", gitConfigArgs("gpg.program")...).Output()
 	if err != nil {
 		return
 	}
 	if !strings.Contains(string(currentGPGProgram), "krgpg") {
 		return
 	}
-	exec.Command("git", "config", "--global", "--unset", "gpg.program").Run()
-	exec.Command("git", "config", "--global", "--unset", "commit.gpgSign").Run()
-	exec.Command("git", "config", "--global", "--unset", "tag.forceSignAnnotated").Run()
+	exec.Command("git", gitConfigArgs("--unset", "gpg.program")...).Run()
+	exec.Command("git", gitConfigArgs("--unset", "commit.gpgSign")...).Run()
+	exec.Command("git", gitConfigArgs("--unset", "tag.forceSignAnnotated")...).Run()
+	exec.Command("git", gitConfigArgs("--unset", "user.signingkey")...).Run()
+
+	// clean up SSH-mode signing settings, if any were configured by `kr codesign --ssh`
+	currentGPGFormat, err := exec.Command("git", gitConfigArgs("gpg.format")...).Output()
+	if err == nil && strings.TrimSpace(string(currentGPGFormat)) == "ssh" {
+		exec.Command("git", gitConfigArgs("--unset", "gpg.format")...).Run()
+		exec.Command("git", gitConfigArgs("--unset", "gpg.ssh.program")...).Run()
+		exec.Command("git", gitConfigArgs("--unset", "gpg.ssh.allowedSignersFile")...).Run()
+	}
+}
+
+// codesignProveTokenPrefix tags the deterministic token format `kr
+// codesign prove` signs: <prefix>:<userID>:<pairingID>:<timestamp>.
+const codesignProveTokenPrefix = "krypton-proof"
+
+// proveToken builds the token `kr codesign prove` asks the phone to sign,
+// and `kr codesign verify-proof` re-derives from a parsed token's fields
+// before trusting the signature check below it.
+func proveToken(userID, pairingID string, timestamp int64) string {
+	return fmt.Sprintf("%s:%s:%s:%d", codesignProveTokenPrefix, userID, pairingID, timestamp)
+}
+
+// codesignProveCommand asks the paired phone to detached-sign a token
+// binding this Krypton pairing to the configured git identity, so the
+// result can be published (a GitHub gist, a .well-known/krypton-proof.txt)
+// as a self-contained proof, without relying on activated-email matching.
+func codesignProveCommand(c *cli.Context) (err error) {
+	stderr := os.Stderr
+	userID := globalGitUserIDOrFatal()
+
+	me, err := RequestMeForceRefresh(&userID)
+	if err != nil {
+		PrintFatal(stderr, err.Error())
+	}
+	if _, pkErr := me.AsciiArmorPGPPublicKey(); pkErr != nil {
+		PrintFatal(stderr, "You do not yet have a PGP public key. Make sure you have the latest version of the Krypton app and try again.")
+	}
+
+	pairingID, err := CurrentPairingID()
+	if err != nil {
+		PrintFatal(stderr, "Could not determine current pairing: "+err.Error())
+	}
+
+	token := proveToken(userID, pairingID, time.Now().Unix())
+
+	signature, err := RequestPGPSignature(&userID, []byte(token))
+	if err != nil {
+		PrintFatal(stderr, "Could not sign proof token: "+err.Error())
+	}
+	proof := token + "\n" + signature + "\n"
+
+	if endpoint := c.String("upload-to"); endpoint != "" {
+		if err = uploadProof(endpoint, proof); err != nil {
+			PrintFatal(stderr, "Failed to upload proof: "+err.Error())
+		}
+		os.Stderr.WriteString(Green("Proof uploaded to "+endpoint+" ✔") + "\r\n")
+		return
+	}
+
+	fmt.Print(proof)
+	return
+}
+
+// uploadProof POSTs proof to a user-configured endpoint, for publishing
+// without an intermediate file (e.g. a gist-creation webhook).
+func uploadProof(endpoint, proof string) error {
+	resp, err := httpClient.Post(endpoint, "text/plain", strings.NewReader(proof))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// codesignVerifyProofCommand re-derives the token in a proof file written
+// by `kr codesign prove` and verifies its signature against the signing
+// userID's PGP public key, fetched via WKD or the configured keyserver --
+// the same key distribution `kr codesign verify` falls back on -- so a
+// proof found standalone (a GitHub gist, a .well-known/krypton-proof.txt)
+// can be checked without ever having paired with the signer's phone.
+func codesignVerifyProofCommand(c *cli.Context) (err error) {
+	stderr := os.Stderr
+	path := c.Args().First()
+	if path == "" {
+		PrintFatal(stderr, "Usage: kr codesign verify-proof <file>")
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		PrintFatal(stderr, err.Error())
+	}
+
+	token, signature, err := parseProof(string(contents))
+	if err != nil {
+		PrintFatal(stderr, err.Error())
+	}
+
+	userID, pairingID, timestamp, err := parseProveToken(token)
+	if err != nil {
+		PrintFatal(stderr, err.Error())
+	}
+	if proveToken(userID, pairingID, timestamp) != token {
+		PrintFatal(stderr, "Proof token is malformed")
+	}
+
+	email := emailFromUserID(userID)
+	if email == "" {
+		PrintFatal(stderr, "Proof token's git identity has no email to look up a PGP key for: "+userID)
+	}
+
+	if err = fetchAndVerifyDetachedPGPSignature(email, []byte(token), signature); err != nil {
+		PrintFatal(stderr, Red("✗ Proof does not verify: "+err.Error()))
+	}
+
+	fmt.Println(Green("✔ Proof verified: " + token))
+	return
+}
+
+// parseProof splits a proof file's token line from its ASCII-armored
+// signature, the two things `kr codesign prove` writes out.
+func parseProof(contents string) (token, signature string, err error) {
+	lines := strings.SplitN(strings.TrimSpace(contents), "\n", 2)
+	if len(lines) != 2 {
+		return "", "", fmt.Errorf("proof file must contain a token line followed by an ASCII-armored signature")
+	}
+	return strings.TrimSpace(lines[0]), strings.TrimSpace(lines[1]), nil
+}
+
+// parseProveToken splits a krypton-proof token back into the fields
+// proveToken joined, so verify-proof can reconstruct and compare it.
+// Parses from the right (timestamp, then pairingID) since userID -- a
+// free-form "Name <email>" string -- may itself contain colons.
+func parseProveToken(token string) (userID, pairingID string, timestamp int64, err error) {
+	prefix := codesignProveTokenPrefix + ":"
+	if !strings.HasPrefix(token, prefix) {
+		return "", "", 0, fmt.Errorf("not a krypton proof token: %s", token)
+	}
+	rest := token[len(prefix):]
+
+	timestampIdx := strings.LastIndex(rest, ":")
+	if timestampIdx == -1 {
+		return "", "", 0, fmt.Errorf("not a krypton proof token: %s", token)
+	}
+	timestamp, err = strconv.ParseInt(rest[timestampIdx+1:], 10, 64)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid timestamp in proof token: %s", rest[timestampIdx+1:])
+	}
+	rest = rest[:timestampIdx]
+
+	pairingIdx := strings.LastIndex(rest, ":")
+	if pairingIdx == -1 {
+		return "", "", 0, fmt.Errorf("not a krypton proof token: %s", token)
+	}
+	return rest[:pairingIdx], rest[pairingIdx+1:], timestamp, nil
+}
+
+// fetchAndVerifyDetachedPGPSignature fetches email's PGP key via WKD or the
+// configured keyserver into a scratch keyring, then verifies signature over
+// message against it. Using a scratch keyring means this doesn't depend on
+// (or pollute) the user's default keyring, and fetching by email rather
+// than trusting a key bundled with the proof means a tampered proof can't
+// smuggle in its own forged "public key".
+func fetchAndVerifyDetachedPGPSignature(email string, message []byte, signature string) error {
+	dir, err := ioutil.TempDir("", "kr-verify-proof")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if out, err := exec.Command("gpg", "--homedir", dir, "--auto-key-locate", "wkd,keyserver", "--keyserver", keyserverURL(), "--locate-keys", email).CombinedOutput(); err != nil {
+		return fmt.Errorf("could not fetch PGP key for %s via WKD or %s: %s", email, keyserverURL(), strings.TrimSpace(string(out)))
+	}
+
+	sigPath := filepath.Join(dir, "proof.sig")
+	msgPath := filepath.Join(dir, "proof.msg")
+	if err = ioutil.WriteFile(sigPath, []byte(signature), 0600); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(msgPath, message, 0600); err != nil {
+		return err
+	}
+
+	out, err := exec.Command("gpg", "--homedir", dir, "--verify", sigPath, msgPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
 }